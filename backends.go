@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/twisted1919/minio-backup/internal/storage"
+	"github.com/twisted1919/minio-backup/internal/storage/azure"
+	"github.com/twisted1919/minio-backup/internal/storage/gcs"
+	"github.com/twisted1919/minio-backup/internal/storage/local"
+	"github.com/twisted1919/minio-backup/internal/storage/s3"
+	"github.com/twisted1919/minio-backup/internal/storage/ssh"
+	"github.com/twisted1919/minio-backup/internal/storage/webdav"
+)
+
+// backendConfig describes a single enabled storage destination. Only the
+// section matching Type needs to be filled in.
+type backendConfig struct {
+	Type    string `json:"type"` // s3, local, ssh, webdav, gcs, azure
+	Name    string `json:"name"`
+	Enabled bool   `json:"enabled"`
+
+	MaxBackups int `json:"max-backups"` // deprecated: use RetentionLastN below
+
+	RetentionLastN   int `json:"retention-last"`
+	RetentionHourly  int `json:"retention-hourly"`
+	RetentionDaily   int `json:"retention-daily"`
+	RetentionWeekly  int `json:"retention-weekly"`
+	RetentionMonthly int `json:"retention-monthly"`
+	RetentionYearly  int `json:"retention-yearly"`
+
+	S3     s3.Config     `json:"s3"`
+	Local  local.Config  `json:"local"`
+	SSH    ssh.Config    `json:"ssh"`
+	WebDAV webdav.Config `json:"webdav"`
+	GCS    gcs.Config    `json:"gcs"`
+	Azure  azure.Config  `json:"azure"`
+}
+
+// buildBackend instantiates the storage.Backend described by bc.
+func buildBackend(bc backendConfig) (storage.Backend, error) {
+	switch bc.Type {
+	case "s3":
+		return s3.New(bc.Name, bc.S3)
+	case "local":
+		return local.New(bc.Name, bc.Local)
+	case "ssh":
+		return ssh.New(bc.Name, bc.SSH)
+	case "webdav":
+		return webdav.New(bc.Name, bc.WebDAV)
+	case "gcs":
+		return gcs.New(bc.Name, bc.GCS)
+	case "azure":
+		return azure.New(bc.Name, bc.Azure)
+	default:
+		return nil, fmt.Errorf("unknown backend type: %q", bc.Type)
+	}
+}
+
+// buildBackends instantiates every enabled backend in config.Backends. If
+// none are configured, it falls back to a single S3/MinIO backend built from
+// the legacy top-level flags, so existing setups keep working unmodified.
+func buildBackends(config *configuration) ([]storage.Backend, error) {
+	if len(config.Backends) == 0 {
+		backend, err := s3.New("default", s3.Config{
+			Endpoint:        config.Endpoint,
+			AccessKeyID:     config.AccessKeyID,
+			SecretAccessKey: config.SecretAccessKey,
+			BucketName:      config.BucketName,
+			UseSSL:          config.UseSSL,
+			Location:        config.Location,
+			NumThreads:      config.StreamConcurrency,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return []storage.Backend{backend}, nil
+	}
+
+	var backends []storage.Backend
+	for _, bc := range config.Backends {
+		if !bc.Enabled {
+			continue
+		}
+		backend, err := buildBackend(bc)
+		if err != nil {
+			return nil, err
+		}
+		backends = append(backends, backend)
+	}
+	return backends, nil
+}
+
+// findBackendConfig returns the configured backendConfig for the named
+// backend, if any.
+func findBackendConfig(config *configuration, name string) (backendConfig, bool) {
+	for _, bc := range config.Backends {
+		if bc.Name == name {
+			return bc, true
+		}
+	}
+	return backendConfig{}, false
+}
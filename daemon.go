@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	log "github.com/sirupsen/logrus"
+	"github.com/twisted1919/minio-backup/internal/daemon"
+)
+
+// runDaemon runs the backup on config.Schedule until the process is killed,
+// serving /healthz and /metrics on config.ListenAddr and guarding every run
+// with a file lock so overlapping schedules (or an accidental concurrent
+// invocation) can't run the backup twice at once.
+func runDaemon(config *configuration) int {
+	if strings.TrimSpace(config.Schedule) == "" {
+		log.Printf("Please specify a schedule: --schedule=...")
+		return 1
+	}
+
+	metrics := daemon.NewMetrics()
+	go func() {
+		if err := daemon.Serve(config.ListenAddr, metrics); err != nil {
+			log.Printf("healthz/metrics server stopped: %s", err)
+		}
+	}()
+
+	// cron.Recover wraps each scheduled run so a panic inside it (a nil
+	// deref, an index error, ...) is logged and swallowed instead of
+	// crashing the whole daemon process.
+	c := cron.New(cron.WithChain(cron.Recover(cron.DefaultLogger)))
+	if _, err := c.AddFunc(config.Schedule, func() { runScheduled(config, metrics) }); err != nil {
+		log.Printf("Invalid schedule %q: %s", config.Schedule, err)
+		return 1
+	}
+
+	log.Printf("Starting daemon for %s on schedule %q, listening on %s", config.BackupFolder, config.Schedule, config.ListenAddr)
+	c.Run()
+	return 0
+}
+
+// runScheduled performs a single backup under config.LockFile and records
+// its outcome in metrics. performBackup's own recover already turns a
+// fatal()/ok() panic into a return; cron.Recover (see runDaemon) is the
+// backstop for anything else that panics during the run.
+func runScheduled(config *configuration, metrics *daemon.Metrics) {
+	err := daemon.WithLock(config.LockFile, func() error {
+		res, code := performBackup(config)
+		runErr := res.lastErr
+		if runErr == nil && code != 0 {
+			runErr = fmt.Errorf("backup exited with code %d", code)
+		}
+		metrics.Observe(time.Since(res.startTime), res.stats.BytesUploaded, res.stats.FilesPruned, runErr)
+		return nil
+	})
+	if err != nil {
+		log.Printf("daemon run: %s", err)
+	}
+}
@@ -0,0 +1,123 @@
+// Package archive creates backup archives, either fully on disk or streamed
+// straight to an io.Writer so a large backup never has to be staged twice.
+package archive
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/mholt/archiver"
+)
+
+// Format selects the archive container/compression to use.
+type Format string
+
+// Supported archive formats.
+const (
+	FormatZip    Format = "zip"
+	FormatTarGz  Format = "tar.gz"
+	FormatTarZst Format = "tar.zst"
+)
+
+// Extension returns the filename suffix for f.
+func (f Format) Extension() string {
+	switch f {
+	case FormatTarGz:
+		return ".tar.gz"
+	case FormatTarZst:
+		return ".tar.zst"
+	default:
+		return ".zip"
+	}
+}
+
+// Streamable reports whether f can be written directly to an io.Writer via
+// Stream. zip cannot, since archiver's zip writer needs random access to the
+// destination file.
+func (f Format) Streamable() bool {
+	return f == FormatTarGz || f == FormatTarZst
+}
+
+// MakeFile creates an archive of sources in format f at destPath.
+func MakeFile(f Format, destPath string, sources []string) error {
+	if f == FormatZip {
+		return archiver.Zip.Make(destPath, sources)
+	}
+
+	out, err := os.Create(destPath)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	return Stream(f, out, sources)
+}
+
+// Stream writes an archive of sources in format f to w, without staging
+// anything on disk. Only Streamable formats are supported.
+func Stream(f Format, w io.Writer, sources []string) error {
+	switch f {
+	case FormatTarGz:
+		gw := gzip.NewWriter(w)
+		defer gw.Close()
+		return tarSources(gw, sources)
+	case FormatTarZst:
+		zw, err := zstd.NewWriter(w)
+		if err != nil {
+			return err
+		}
+		defer zw.Close()
+		return tarSources(zw, sources)
+	default:
+		return fmt.Errorf("streaming is not supported for archive format %q", f)
+	}
+}
+
+// tarSources writes a tar stream of sources to w.
+func tarSources(w io.Writer, sources []string) error {
+	tw := tar.NewWriter(w)
+	defer tw.Close()
+
+	for _, src := range sources {
+		base := filepath.Dir(src)
+		err := filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+
+			header, err := tar.FileInfoHeader(info, "")
+			if err != nil {
+				return err
+			}
+			if header.Name, err = filepath.Rel(base, path); err != nil {
+				return err
+			}
+
+			if err := tw.WriteHeader(header); err != nil {
+				return err
+			}
+			if info.IsDir() {
+				return nil
+			}
+
+			f, err := os.Open(path)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+
+			_, err = io.Copy(tw, f)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
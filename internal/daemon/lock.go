@@ -0,0 +1,26 @@
+package daemon
+
+import (
+	"fmt"
+
+	"github.com/gofrs/flock"
+)
+
+// WithLock runs fn while holding an exclusive lock on the file at path, so
+// an overlapping cron tick or an accidental concurrent invocation can't run
+// a second backup at the same time. If the lock is already held, fn is
+// skipped and WithLock returns nil, leaving the other run to finish.
+func WithLock(path string, fn func() error) error {
+	lock := flock.New(path)
+
+	locked, err := lock.TryLock()
+	if err != nil {
+		return fmt.Errorf("acquiring lock %s: %w", path, err)
+	}
+	if !locked {
+		return nil
+	}
+	defer lock.Unlock()
+
+	return fn()
+}
@@ -0,0 +1,112 @@
+package encrypt
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"filippo.io/age"
+)
+
+func encryptAge(c Config, inPath string, outPath string) error {
+	recipient, err := ageRecipient(c)
+	if err != nil {
+		return err
+	}
+
+	src, err := os.Open(inPath)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.Create(outPath)
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+
+	w, err := age.Encrypt(dst, recipient)
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+
+	_, err = io.Copy(w, src)
+	return err
+}
+
+func decryptAge(c Config, inPath string, outPath string) error {
+	identity, err := ageIdentity(c)
+	if err != nil {
+		return err
+	}
+
+	src, err := os.Open(inPath)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	r, err := age.Decrypt(src, identity)
+	if err != nil {
+		return err
+	}
+
+	dst, err := os.Create(outPath)
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+
+	_, err = io.Copy(dst, r)
+	return err
+}
+
+// ageRecipient resolves the recipient to encrypt against: the first
+// recipient in the age recipients file at c.PublicKey when configured,
+// otherwise a passphrase-derived scrypt recipient.
+func ageRecipient(c Config) (age.Recipient, error) {
+	if c.PublicKey == "" {
+		return age.NewScryptRecipient(c.Passphrase)
+	}
+
+	f, err := os.Open(c.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	recipients, err := age.ParseRecipients(f)
+	if err != nil {
+		return nil, err
+	}
+	if len(recipients) == 0 {
+		return nil, fmt.Errorf("no recipients found in %s", c.PublicKey)
+	}
+	return recipients[0], nil
+}
+
+// ageIdentity resolves the matching identity used to decrypt: the first
+// identity in the age identity file at c.PrivateKey when configured,
+// otherwise a passphrase-derived scrypt identity.
+func ageIdentity(c Config) (age.Identity, error) {
+	if c.PrivateKey == "" {
+		return age.NewScryptIdentity(c.Passphrase)
+	}
+
+	f, err := os.Open(c.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	identities, err := age.ParseIdentities(f)
+	if err != nil {
+		return nil, err
+	}
+	if len(identities) == 0 {
+		return nil, fmt.Errorf("no identities found in %s", c.PrivateKey)
+	}
+	return identities[0], nil
+}
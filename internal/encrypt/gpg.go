@@ -0,0 +1,148 @@
+package encrypt
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/armor"
+)
+
+func encryptGPGPublicKey(c Config, inPath string, outPath string) error {
+	keyFile, err := os.Open(c.PublicKey)
+	if err != nil {
+		return err
+	}
+	defer keyFile.Close()
+
+	entityList, err := openpgp.ReadArmoredKeyRing(keyFile)
+	if err != nil {
+		return err
+	}
+
+	src, err := os.Open(inPath)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.Create(outPath)
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+
+	armorWriter, err := armor.Encode(dst, "PGP MESSAGE", nil)
+	if err != nil {
+		return err
+	}
+	defer armorWriter.Close()
+
+	cipherWriter, err := openpgp.Encrypt(armorWriter, entityList, nil, nil, nil)
+	if err != nil {
+		return err
+	}
+	defer cipherWriter.Close()
+
+	_, err = io.Copy(cipherWriter, src)
+	return err
+}
+
+func decryptGPGPublicKey(c Config, inPath string, outPath string) error {
+	keyFile, err := os.Open(c.PublicKey)
+	if err != nil {
+		return err
+	}
+	defer keyFile.Close()
+
+	entityList, err := openpgp.ReadArmoredKeyRing(keyFile)
+	if err != nil {
+		return err
+	}
+
+	promptFunc := func(keys []openpgp.Key, symmetric bool) ([]byte, error) {
+		return []byte(c.Passphrase), nil
+	}
+
+	src, err := os.Open(inPath)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	block, err := armor.Decode(src)
+	if err != nil {
+		return err
+	}
+
+	md, err := openpgp.ReadMessage(block.Body, entityList, promptFunc, nil)
+	if err != nil {
+		return err
+	}
+
+	plaintext, err := ioutil.ReadAll(md.UnverifiedBody)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(outPath, plaintext, 0644)
+}
+
+func encryptGPGSymmetric(c Config, inPath string, outPath string) error {
+	src, err := os.Open(inPath)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.Create(outPath)
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+
+	armorWriter, err := armor.Encode(dst, "PGP MESSAGE", nil)
+	if err != nil {
+		return err
+	}
+	defer armorWriter.Close()
+
+	cipherWriter, err := openpgp.SymmetricallyEncrypt(armorWriter, []byte(c.Passphrase), nil, nil)
+	if err != nil {
+		return err
+	}
+	defer cipherWriter.Close()
+
+	_, err = io.Copy(cipherWriter, src)
+	return err
+}
+
+func decryptGPGSymmetric(c Config, inPath string, outPath string) error {
+	promptFunc := func(keys []openpgp.Key, symmetric bool) ([]byte, error) {
+		return []byte(c.Passphrase), nil
+	}
+
+	src, err := os.Open(inPath)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	block, err := armor.Decode(src)
+	if err != nil {
+		return err
+	}
+
+	md, err := openpgp.ReadMessage(block.Body, nil, promptFunc, nil)
+	if err != nil {
+		return err
+	}
+
+	plaintext, err := ioutil.ReadAll(md.UnverifiedBody)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(outPath, plaintext, 0644)
+}
@@ -0,0 +1,29 @@
+// Package hooks runs operator-configured shell commands (pre/post backup,
+// on-error) with backup state exposed through the environment.
+package hooks
+
+import (
+	"context"
+	"os"
+	"os/exec"
+)
+
+// Run executes command through "sh -c", with env merged on top of the
+// current process's environment, streaming output to stdout/stderr. It is a
+// no-op when command is empty.
+func Run(ctx context.Context, command string, env map[string]string) error {
+	if command == "" {
+		return nil
+	}
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	cmd.Env = os.Environ()
+	for k, v := range env {
+		cmd.Env = append(cmd.Env, k+"="+v)
+	}
+
+	return cmd.Run()
+}
@@ -0,0 +1,75 @@
+// Package notify renders backup run results into a notification message and
+// dispatches it by email and/or via shoutrrr (Slack, Discord, Telegram,
+// Matrix, Teams, Pushover, generic webhooks, ...).
+package notify
+
+import (
+	"bytes"
+	_ "embed"
+	"text/template"
+	"time"
+
+	"github.com/containrrr/shoutrrr"
+)
+
+//go:embed templates/success.tmpl
+var defaultSuccessTemplate string
+
+//go:embed templates/failure.tmpl
+var defaultFailureTemplate string
+
+// Stats carries the metrics surfaced to notification templates.
+type Stats struct {
+	BytesUploaded int64
+	ArchiveSize   int64
+	FilesPruned   int
+	Elapsed       time.Duration
+}
+
+// Data is the struct notification templates are rendered against.
+type Data struct {
+	Config    interface{}
+	Messages  []string
+	Stats     Stats
+	Hostname  string
+	StartTime time.Time
+	EndTime   time.Time
+	Error     error
+}
+
+// DefaultTemplate returns the embedded success or failure template, used
+// whenever the operator hasn't configured an override file.
+func DefaultTemplate(hasError bool) string {
+	if hasError {
+		return defaultFailureTemplate
+	}
+	return defaultSuccessTemplate
+}
+
+// Render executes templateText against data and returns the resulting
+// message body.
+func Render(templateText string, data Data) (string, error) {
+	tmpl, err := template.New("notification").Parse(templateText)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+// Dispatch sends message to every configured shoutrrr URL, returning the
+// first error encountered (if any) after attempting them all.
+func Dispatch(urls []string, message string) error {
+	var firstErr error
+	for _, u := range urls {
+		if err := shoutrrr.Send(u, message); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
@@ -0,0 +1,34 @@
+package retention
+
+import (
+	"strings"
+	"time"
+)
+
+// nameTimestampLayout matches the "2006-01-02.15-04-05" timestamp minio-backup
+// embeds in archive names (see main.go's archiveName format).
+const nameTimestampLayout = "2006-01-02.15-04-05"
+
+// TimestampFromName extracts the timestamp embedded in a "<prefix><ts><ext>"
+// object key, falling back to zero time when the key doesn't match. It is
+// used as a Policy.TimestampOf source when a backend's LastModified isn't
+// trustworthy (e.g. a WebDAV server that doesn't preserve it).
+func TimestampFromName(prefix string, key string) (time.Time, bool) {
+	rest := strings.TrimPrefix(key, prefix)
+	if rest == key && prefix != "" {
+		return time.Time{}, false
+	}
+
+	// The timestamp is a fixed-width prefix of rest; any extension(s) that
+	// follow (".zip", ".tar.gz", ".tar.gz.gpg", ...) are ignored.
+	if len(rest) < len(nameTimestampLayout) {
+		return time.Time{}, false
+	}
+
+	ts, err := time.ParseInLocation(nameTimestampLayout, rest[:len(nameTimestampLayout)], time.Local)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	return ts, true
+}
@@ -0,0 +1,117 @@
+// Package retention decides which backups survive a pruning pass, following
+// the grandfather-father-son scheme used by tools like restic and borg:
+// keep the newest backup, plus the newest backup in each of the last N
+// hourly/daily/weekly/monthly/yearly windows.
+package retention
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/twisted1919/minio-backup/internal/storage"
+)
+
+// Policy configures which backups survive a pruning pass. A zero count for
+// any field disables that rule.
+type Policy struct {
+	Last    int
+	Hourly  int
+	Daily   int
+	Weekly  int
+	Monthly int
+	Yearly  int
+
+	// Leeway is a grace period: objects younger than Leeway are never
+	// pruned, even if no retention rule above would otherwise keep them.
+	// This protects a just-uploaded backup from being pruned due to clock
+	// skew between the local clock and a backend's reported timestamps.
+	Leeway time.Duration
+
+	// TimestampOf resolves the timestamp an object is bucketed by. When
+	// nil, Object.LastModified is used as-is.
+	TimestampOf func(storage.Object) time.Time
+}
+
+// Plan is the outcome of applying a Policy to a set of objects.
+type Plan struct {
+	Keep   []storage.Object
+	Remove []storage.Object
+}
+
+type dated struct {
+	object storage.Object
+	ts     time.Time
+}
+
+// Apply decides which objects to keep and which to remove. now is the
+// reference point Leeway is measured against. A policy with every counter
+// at zero means "no retention rule configured" and keeps everything,
+// rather than pruning the entire set.
+func Apply(policy Policy, objects []storage.Object, now time.Time) Plan {
+	if policy.Last == 0 && policy.Hourly == 0 && policy.Daily == 0 && policy.Weekly == 0 && policy.Monthly == 0 && policy.Yearly == 0 {
+		return Plan{Keep: objects}
+	}
+
+	timestampOf := policy.TimestampOf
+	if timestampOf == nil {
+		timestampOf = func(o storage.Object) time.Time { return o.LastModified }
+	}
+
+	timestamped := make([]dated, 0, len(objects))
+	for _, o := range objects {
+		timestamped = append(timestamped, dated{object: o, ts: timestampOf(o)})
+	}
+	sort.Slice(timestamped, func(i, j int) bool { return timestamped[i].ts.After(timestamped[j].ts) })
+
+	keep := make(map[string]bool, len(timestamped))
+
+	for i := 0; i < policy.Last && i < len(timestamped); i++ {
+		keep[timestamped[i].object.Key] = true
+	}
+
+	markWindows(timestamped, keep, policy.Hourly, func(t time.Time) string { return t.Format("2006-01-02T15") })
+	markWindows(timestamped, keep, policy.Daily, func(t time.Time) string { return t.Format("2006-01-02") })
+	markWindows(timestamped, keep, policy.Weekly, func(t time.Time) string {
+		year, week := t.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", year, week)
+	})
+	markWindows(timestamped, keep, policy.Monthly, func(t time.Time) string { return t.Format("2006-01") })
+	markWindows(timestamped, keep, policy.Yearly, func(t time.Time) string { return t.Format("2006") })
+
+	var plan Plan
+	for _, d := range timestamped {
+		if keep[d.object.Key] {
+			plan.Keep = append(plan.Keep, d.object)
+			continue
+		}
+		if now.Sub(d.ts) < policy.Leeway {
+			plan.Keep = append(plan.Keep, d.object)
+			continue
+		}
+		plan.Remove = append(plan.Remove, d.object)
+	}
+
+	return plan
+}
+
+// markWindows keeps the newest object in each of the first `count` distinct
+// time windows (as produced by windowKey), walking objects newest-first.
+func markWindows(timestamped []dated, keep map[string]bool, count int, windowKey func(time.Time) string) {
+	if count <= 0 {
+		return
+	}
+
+	seen := make(map[string]bool, count)
+	for _, d := range timestamped {
+		w := windowKey(d.ts)
+		if seen[w] {
+			continue
+		}
+		seen[w] = true
+		keep[d.object.Key] = true
+		if len(seen) >= count {
+			return
+		}
+	}
+}
@@ -0,0 +1,93 @@
+// Package azure implements the storage.Backend interface on top of Azure
+// Blob Storage.
+package azure
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"github.com/Azure/azure-storage-blob-go/azblob"
+	gostorage "github.com/twisted1919/minio-backup/internal/storage"
+)
+
+// Config holds the settings for an Azure Blob Storage backend.
+type Config struct {
+	AccountName   string `json:"account-name"`
+	AccountKey    string `json:"account-key"`
+	ContainerName string `json:"container-name"`
+}
+
+// Backend uploads archives to an Azure Blob Storage container.
+type Backend struct {
+	name      string
+	config    Config
+	container azblob.ContainerURL
+}
+
+// New returns a ready to use Backend.
+func New(name string, config Config) (*Backend, error) {
+	credential, err := azblob.NewSharedKeyCredential(config.AccountName, config.AccountKey)
+	if err != nil {
+		return nil, err
+	}
+
+	pipeline := azblob.NewPipeline(credential, azblob.PipelineOptions{})
+	containerURL, err := url.Parse(fmt.Sprintf("https://%s.blob.core.windows.net/%s", config.AccountName, config.ContainerName))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Backend{name: name, config: config, container: azblob.NewContainerURL(*containerURL, pipeline)}, nil
+}
+
+// Name implements storage.Backend.
+func (b *Backend) Name() string {
+	return b.name
+}
+
+// Upload implements storage.Backend.
+func (b *Backend) Upload(ctx context.Context, r io.Reader, size int64, remoteName string) error {
+	blockBlobURL := b.container.NewBlockBlobURL(remoteName)
+	_, err := azblob.UploadStreamToBlockBlob(ctx, r, blockBlobURL, azblob.UploadStreamToBlockBlobOptions{
+		BufferSize: 4 * 1024 * 1024,
+		MaxBuffers: 4,
+	})
+	return err
+}
+
+// List implements storage.Backend.
+func (b *Backend) List(ctx context.Context, prefix string) ([]gostorage.Object, error) {
+	var objects []gostorage.Object
+
+	for marker := (azblob.Marker{}); marker.NotDone(); {
+		resp, err := b.container.ListBlobsFlatSegment(ctx, marker, azblob.ListBlobsSegmentOptions{Prefix: prefix})
+		if err != nil {
+			return nil, err
+		}
+		marker = resp.NextMarker
+
+		for _, blob := range resp.Segment.BlobItems {
+			if !strings.HasPrefix(blob.Name, prefix) {
+				continue
+			}
+			objects = append(objects, gostorage.Object{
+				Key:          blob.Name,
+				Size:         *blob.Properties.ContentLength,
+				LastModified: blob.Properties.LastModified,
+			})
+		}
+	}
+
+	return objects, nil
+}
+
+// Delete implements storage.Backend.
+func (b *Backend) Delete(ctx context.Context, key string) error {
+	_, err := b.container.NewBlockBlobURL(key).Delete(ctx, azblob.DeleteSnapshotsOptionNone, azblob.BlobAccessConditions{})
+	return err
+}
+
+var _ gostorage.Backend = (*Backend)(nil)
@@ -0,0 +1,38 @@
+// Package storage defines the pluggable backend abstraction used by
+// minio-backup to push archives to one or more remote destinations.
+package storage
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// Object describes a single remote file as reported by a Backend's List.
+type Object struct {
+	Key          string
+	Size         int64
+	LastModified time.Time
+}
+
+// Backend is implemented by every storage destination minio-backup knows how
+// to talk to (S3/MinIO, local disk, SSH, WebDAV, GCS, Azure, ...). The main
+// loop runs the same upload/prune cycle against every enabled Backend, which
+// is what allows a single run to mirror an archive to several destinations.
+type Backend interface {
+	// Name identifies the backend in log messages and per-backend retention
+	// counters. It is the name configured by the operator, not the backend
+	// type (e.g. "offsite-ssh", not "ssh").
+	Name() string
+
+	// Upload reads r and stores it under remoteName. size is the number of
+	// bytes r will yield, or -1 when unknown (e.g. a backup streamed
+	// straight out of the archiver without being staged on disk first).
+	Upload(ctx context.Context, r io.Reader, size int64, remoteName string) error
+
+	// List returns every object whose key starts with prefix.
+	List(ctx context.Context, prefix string) ([]Object, error)
+
+	// Delete removes the object identified by key.
+	Delete(ctx context.Context, key string) error
+}
@@ -0,0 +1,92 @@
+// Package gcs implements the storage.Backend interface on top of Google
+// Cloud Storage.
+package gcs
+
+import (
+	"context"
+	"io"
+	"strings"
+
+	"cloud.google.com/go/storage"
+	gostorage "github.com/twisted1919/minio-backup/internal/storage"
+	"google.golang.org/api/iterator"
+	"google.golang.org/api/option"
+)
+
+// Config holds the settings for a GCS backend.
+type Config struct {
+	BucketName      string `json:"bucket-name"`
+	CredentialsFile string `json:"credentials-file"`
+}
+
+// Backend uploads archives to a Google Cloud Storage bucket.
+type Backend struct {
+	name   string
+	config Config
+	client *storage.Client
+}
+
+// New returns a ready to use Backend.
+func New(name string, config Config) (*Backend, error) {
+	ctx := context.Background()
+
+	var opts []option.ClientOption
+	if config.CredentialsFile != "" {
+		opts = append(opts, option.WithCredentialsFile(config.CredentialsFile))
+	}
+
+	client, err := storage.NewClient(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Backend{name: name, config: config, client: client}, nil
+}
+
+// Name implements storage.Backend.
+func (b *Backend) Name() string {
+	return b.name
+}
+
+// Upload implements storage.Backend.
+func (b *Backend) Upload(ctx context.Context, r io.Reader, size int64, remoteName string) error {
+	w := b.client.Bucket(b.config.BucketName).Object(remoteName).NewWriter(ctx)
+	if _, err := io.Copy(w, r); err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
+
+// List implements storage.Backend.
+func (b *Backend) List(ctx context.Context, prefix string) ([]gostorage.Object, error) {
+	var objects []gostorage.Object
+
+	it := b.client.Bucket(b.config.BucketName).Objects(ctx, &storage.Query{Prefix: prefix})
+	for {
+		attrs, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !strings.HasPrefix(attrs.Name, prefix) {
+			continue
+		}
+		objects = append(objects, gostorage.Object{
+			Key:          attrs.Name,
+			Size:         attrs.Size,
+			LastModified: attrs.Updated,
+		})
+	}
+
+	return objects, nil
+}
+
+// Delete implements storage.Backend.
+func (b *Backend) Delete(ctx context.Context, key string) error {
+	return b.client.Bucket(b.config.BucketName).Object(key).Delete(ctx)
+}
+
+var _ gostorage.Backend = (*Backend)(nil)
@@ -0,0 +1,89 @@
+// Package local implements the storage.Backend interface by copying
+// archives to another path on disk (e.g. a mounted NFS share or a second
+// drive).
+package local
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/twisted1919/minio-backup/internal/storage"
+)
+
+// Config holds the settings for a local-disk backend.
+type Config struct {
+	Directory string `json:"directory"`
+}
+
+// Backend copies archives into a directory on the local filesystem.
+type Backend struct {
+	name   string
+	config Config
+}
+
+// New returns a ready to use Backend, creating the destination directory if
+// it does not already exist.
+func New(name string, config Config) (*Backend, error) {
+	if err := os.MkdirAll(config.Directory, 0755); err != nil {
+		return nil, err
+	}
+	return &Backend{name: name, config: config}, nil
+}
+
+// Name implements storage.Backend.
+func (b *Backend) Name() string {
+	return b.name
+}
+
+// Upload implements storage.Backend.
+func (b *Backend) Upload(ctx context.Context, r io.Reader, size int64, remoteName string) error {
+	dst, err := os.Create(filepath.Join(b.config.Directory, remoteName))
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+
+	_, err = io.Copy(dst, r)
+	return err
+}
+
+// List implements storage.Backend.
+func (b *Backend) List(ctx context.Context, prefix string) ([]storage.Object, error) {
+	entries, err := os.ReadDir(b.config.Directory)
+	if err != nil {
+		return nil, err
+	}
+
+	var objects []storage.Object
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasPrefix(entry.Name(), prefix) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+		objects = append(objects, storage.Object{
+			Key:          entry.Name(),
+			Size:         info.Size(),
+			LastModified: info.ModTime(),
+		})
+	}
+
+	sort.Slice(objects, func(i, j int) bool {
+		return objects[i].LastModified.Before(objects[j].LastModified)
+	})
+
+	return objects, nil
+}
+
+// Delete implements storage.Backend.
+func (b *Backend) Delete(ctx context.Context, key string) error {
+	return os.Remove(filepath.Join(b.config.Directory, key))
+}
+
+var _ storage.Backend = (*Backend)(nil)
@@ -0,0 +1,97 @@
+// Package s3 implements the storage.Backend interface on top of any
+// S3-compatible endpoint (MinIO, AWS S3, DigitalOcean Spaces, ...).
+package s3
+
+import (
+	"context"
+	"io"
+
+	"github.com/minio/minio-go"
+	"github.com/twisted1919/minio-backup/internal/storage"
+)
+
+// Config holds the settings needed to talk to an S3-compatible endpoint.
+//
+// There is no per-call multipart part-size knob: the pinned minio-go v6
+// client only exposes NumThreads on PutObjectOptions and picks part sizes
+// itself (see its optimalPartInfo). A StreamPartSize setting was briefly
+// added and then removed for this reason; NumThreads is the only upload
+// concurrency tuning this client version supports.
+type Config struct {
+	Endpoint        string `json:"endpoint"`
+	AccessKeyID     string `json:"access-key-id"`
+	SecretAccessKey string `json:"secret-access-key"`
+	BucketName      string `json:"bucket-name"`
+	UseSSL          bool   `json:"ssl"`
+	Location        string `json:"location"`
+
+	// NumThreads tunes the multipart upload used when streaming an archive
+	// of unknown size directly into the bucket. Defaults to minio-go's own
+	// default when left at zero.
+	NumThreads uint `json:"num-threads"`
+}
+
+// Backend talks to an S3-compatible endpoint via minio-go.
+type Backend struct {
+	name   string
+	config Config
+	client *minio.Client
+}
+
+// New creates the bucket (if it does not already exist) and returns a ready
+// to use Backend.
+func New(name string, config Config) (*Backend, error) {
+	client, err := minio.New(config.Endpoint, config.AccessKeyID, config.SecretAccessKey, config.UseSSL)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := client.MakeBucket(config.BucketName, config.Location); err != nil {
+		exists, existsErr := client.BucketExists(config.BucketName)
+		if existsErr != nil || !exists {
+			return nil, err
+		}
+	}
+
+	return &Backend{name: name, config: config, client: client}, nil
+}
+
+// Name implements storage.Backend.
+func (b *Backend) Name() string {
+	return b.name
+}
+
+// Upload implements storage.Backend.
+func (b *Backend) Upload(ctx context.Context, r io.Reader, size int64, remoteName string) error {
+	_, err := b.client.PutObject(b.config.BucketName, remoteName, r, size, minio.PutObjectOptions{
+		ContentType: "application/octet-stream",
+		NumThreads:  b.config.NumThreads,
+	})
+	return err
+}
+
+// List implements storage.Backend.
+func (b *Backend) List(ctx context.Context, prefix string) ([]storage.Object, error) {
+	doneCh := make(chan struct{})
+	defer close(doneCh)
+
+	var objects []storage.Object
+	for object := range b.client.ListObjectsV2(b.config.BucketName, prefix, true, doneCh) {
+		if object.Err != nil {
+			return nil, object.Err
+		}
+		objects = append(objects, storage.Object{
+			Key:          object.Key,
+			Size:         object.Size,
+			LastModified: object.LastModified,
+		})
+	}
+	return objects, nil
+}
+
+// Delete implements storage.Backend.
+func (b *Backend) Delete(ctx context.Context, key string) error {
+	return b.client.RemoveObject(b.config.BucketName, key)
+}
+
+var _ storage.Backend = (*Backend)(nil)
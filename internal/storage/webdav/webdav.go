@@ -0,0 +1,76 @@
+// Package webdav implements the storage.Backend interface against a WebDAV
+// server (e.g. Nextcloud, ownCloud, or a plain WebDAV share).
+package webdav
+
+import (
+	"context"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/studio-b12/gowebdav"
+	"github.com/twisted1919/minio-backup/internal/storage"
+)
+
+// Config holds the settings for a WebDAV backend.
+type Config struct {
+	URL       string `json:"url"`
+	Username  string `json:"username"`
+	Password  string `json:"password"`
+	RemoteDir string `json:"remote-dir"`
+}
+
+// Backend uploads archives to a directory on a WebDAV server.
+type Backend struct {
+	name   string
+	config Config
+	client *gowebdav.Client
+}
+
+// New returns a ready to use Backend, creating the remote directory if it
+// does not already exist.
+func New(name string, config Config) (*Backend, error) {
+	client := gowebdav.NewClient(config.URL, config.Username, config.Password)
+	if err := client.MkdirAll(config.RemoteDir, 0755); err != nil {
+		return nil, err
+	}
+	return &Backend{name: name, config: config, client: client}, nil
+}
+
+// Name implements storage.Backend.
+func (b *Backend) Name() string {
+	return b.name
+}
+
+// Upload implements storage.Backend.
+func (b *Backend) Upload(ctx context.Context, r io.Reader, size int64, remoteName string) error {
+	return b.client.WriteStream(path.Join(b.config.RemoteDir, remoteName), r, 0644)
+}
+
+// List implements storage.Backend.
+func (b *Backend) List(ctx context.Context, prefix string) ([]storage.Object, error) {
+	entries, err := b.client.ReadDir(b.config.RemoteDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var objects []storage.Object
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasPrefix(entry.Name(), prefix) {
+			continue
+		}
+		objects = append(objects, storage.Object{
+			Key:          entry.Name(),
+			Size:         entry.Size(),
+			LastModified: entry.ModTime(),
+		})
+	}
+	return objects, nil
+}
+
+// Delete implements storage.Backend.
+func (b *Backend) Delete(ctx context.Context, key string) error {
+	return b.client.Remove(path.Join(b.config.RemoteDir, key))
+}
+
+var _ storage.Backend = (*Backend)(nil)
@@ -1,20 +1,29 @@
 package main
 
 import (
+	"context"
 	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
-	"github.com/minio/minio-go"
+	log "github.com/sirupsen/logrus"
+	"github.com/twisted1919/minio-backup/internal/archive"
+	"github.com/twisted1919/minio-backup/internal/daemon"
+	"github.com/twisted1919/minio-backup/internal/dockerctl"
+	"github.com/twisted1919/minio-backup/internal/encrypt"
+	"github.com/twisted1919/minio-backup/internal/hooks"
+	"github.com/twisted1919/minio-backup/internal/notify"
+	"github.com/twisted1919/minio-backup/internal/retention"
+	"github.com/twisted1919/minio-backup/internal/storage"
 	"gopkg.in/gomail.v2"
+	"io"
 	"io/ioutil"
-	"log"
 	"os"
 	"os/user"
 	"path/filepath"
 	"strings"
 	"time"
-	"github.com/mholt/archiver"
 )
 
 // Constants
@@ -34,10 +43,40 @@ type configuration struct {
 	UseSSL          bool   `json:"ssl"`
 	Location        string `json:"location"`
 
-	MaxBackups   int    `json:"max-backups"`
+	MaxBackups   int    `json:"max-backups"` // deprecated: use RetentionLastN
 	BackupPrefix string `json:"backup-prefix"`
 	BackupFolder string `json:"backup-folder"`
 
+	RetentionLastN   int    `json:"retention-last"`
+	RetentionHourly  int    `json:"retention-hourly"`
+	RetentionDaily   int    `json:"retention-daily"`
+	RetentionWeekly  int    `json:"retention-weekly"`
+	RetentionMonthly int    `json:"retention-monthly"`
+	RetentionYearly  int    `json:"retention-yearly"`
+	PruningLeeway    string `json:"pruning-leeway"` // e.g. "1h", grace period before a backup becomes eligible for pruning
+	PruningDryRun    bool   `json:"pruning-dry-run"`
+
+	// RetentionTimestampFromName buckets backups by the timestamp embedded in
+	// their object name instead of the backend's reported LastModified, for
+	// backends that don't preserve it reliably (e.g. some WebDAV servers).
+	// Falls back to LastModified for any object whose name doesn't match.
+	RetentionTimestampFromName bool `json:"retention-timestamp-from-name"`
+
+	ArchiveFormat     string `json:"archive-format"` // zip, tar.gz or tar.zst
+	Streaming         bool   `json:"streaming"`      // pipe the archive straight into the upload instead of staging it on disk
+	StreamConcurrency uint   `json:"stream-concurrency"`
+	TempDir           string `json:"temp-dir"` // used when streaming is disabled (or not possible)
+
+	// Backends lists the storage destinations to use. When empty, a single
+	// S3/MinIO backend is built from the fields above; when not empty, that
+	// backend is not built and only the enabled entries here are used.
+	Backends []backendConfig `json:"backends"`
+
+	EncryptionMode       string `json:"encryption-mode"`        // none, gpg, age, symmetric
+	EncryptionPublicKey  string `json:"encryption-public-key"`  // path to the gpg keyring / age recipients file used to encrypt
+	EncryptionPrivateKey string `json:"encryption-private-key"` // path to the age identity file used to decrypt (gpg keeps both in EncryptionPublicKey's keyring)
+	EncryptionPassphrase string `json:"encryption-passphrase"`
+
 	SmtpHostname  string `json:"smtp-hostname"`
 	SmtpPort      int    `json:"smtp-port"`
 	SmtpUsername  string `json:"smtp-username"`
@@ -47,6 +86,22 @@ type configuration struct {
 	NotifySuccess bool   `json:"notify-success"`
 	NotifyError   bool   `json:"notify-error"`
 	NotifyEmail   string `json:"notify-email"`
+
+	NotificationTemplateSuccess string   `json:"notification-template-success"` // path to a text/template file, defaults to an embedded template
+	NotificationTemplateFailure string   `json:"notification-template-failure"` // path to a text/template file, defaults to an embedded template
+	NotificationURLs            []string `json:"notification-urls"`             // shoutrrr service URLs (Slack, Discord, Telegram, Matrix, Teams, Pushover, webhooks, ...)
+
+	PreBackupCommand  string `json:"pre-backup-command"`  // run before archiving, e.g. to dump a database into BackupFolder
+	PostBackupCommand string `json:"post-backup-command"` // run after a successful backup
+	OnErrorCommand    string `json:"on-error-command"`    // run if the backup fails
+
+	StopContainers     bool   `json:"stop-containers"`      // stop matching docker containers for the duration of the backup, then restart them
+	StopContainerLabel string `json:"stop-container-label"` // "key=value" label of the docker containers to stop/restart
+
+	Daemon     bool   `json:"daemon"`      // run in-process on Schedule instead of performing a single backup and exiting
+	Schedule   string `json:"schedule"`    // cron expression, e.g. "0 3 * * *", required when Daemon is true
+	ListenAddr string `json:"listen-addr"` // address the /healthz and /metrics endpoints listen on in daemon mode
+	LockFile   string `json:"lock-file"`   // file lock path preventing overlapping runs
 }
 
 // Helper for loading the configuration from file
@@ -91,11 +146,19 @@ func (c *configuration) loadFromJSONFile(configFile string) {
 // Helper to create a new config object
 func newConfiguration() *configuration {
 	return &configuration{
-		UseSSL:       true,
-		Location:     "us-east-1",
-		MaxBackups:   5,
-		BackupPrefix: "backup-",
-		SmtpPort:     25,
+		UseSSL:         true,
+		Location:       "us-east-1",
+		MaxBackups:     5,
+		BackupPrefix:   "backup-",
+		SmtpPort:       25,
+		EncryptionMode: string(encrypt.ModeNone),
+		ArchiveFormat:  string(archive.FormatZip),
+		TempDir:        "/tmp",
+
+		StopContainerLabel: dockerctl.DefaultStopLabel,
+
+		ListenAddr: ":9112",
+		LockFile:   "/var/lock/minio-backup.lock",
 	}
 }
 
@@ -116,34 +179,60 @@ func newResultMessage(messageType string, message string) resultMessage {
 
 // Structure for the result
 type result struct {
-	config   *configuration
-	messages []resultMessage
+	config      *configuration
+	messages    []resultMessage
+	startTime   time.Time
+	stats       notify.Stats
+	lastErr     error
+	archivePath string
+}
+
+// hookEnv returns the environment variables exposed to PreBackupCommand,
+// PostBackupCommand and OnErrorCommand.
+func (r *result) hookEnv(status string) map[string]string {
+	return map[string]string{
+		"MINIO_BACKUP_STATUS":       status,
+		"MINIO_BACKUP_FOLDER":       r.config.BackupFolder,
+		"MINIO_BACKUP_ARCHIVE_PATH": r.archivePath,
+	}
 }
 
+// exitCode is panicked by fatal/ok so that deferred cleanup (e.g. restarting
+// containers stopped for the backup) runs before the process actually exits;
+// it is recovered by performBackup, which returns it as the exit code that
+// main passes to os.Exit.
+type exitCode int
+
 // Helper to add a message to the store
 func (r *result) message(rm resultMessage) *result {
 	log.Println(rm.message)
 	r.messages = append(r.messages, rm)
+	if rm.messageType == resultError {
+		r.lastErr = errors.New(rm.message)
+	}
 	return r
 }
 
 // Stop execution with error code
 func (r *result) fatal() {
-	os.Exit(1)
+	if err := hooks.Run(context.Background(), r.config.OnErrorCommand, r.hookEnv(resultError)); err != nil {
+		log.Printf("on-error-command failed: %s", err)
+	}
+	panic(exitCode(1))
 }
 
 // Stop execution with success code
 func (r *result) ok() {
-	os.Exit(0)
+	if err := hooks.Run(context.Background(), r.config.PostBackupCommand, r.hookEnv(resultSuccess)); err != nil {
+		log.Printf("post-backup-command failed: %s", err)
+	}
+	panic(exitCode(0))
 }
 
-// Email the results if allowed and possible
+// email renders the result into a notification message and dispatches it by
+// SMTP and/or shoutrrr, if allowed and possible.
 func (r *result) email() *result {
 
-	if r.config.SmtpHostname == "" || r.config.SmtpFromEmail == "" || r.config.NotifyEmail == "" {
-		return r
-	}
-
 	if len(r.messages) == 0 {
 		return r
 	}
@@ -169,32 +258,87 @@ func (r *result) email() *result {
 		hostname = name
 	}
 
-	subject := fmt.Sprintf("[%s]: Backup status", hostname)
-	message := ""
-
 	var messages []string
 	for _, m := range r.messages {
 		messages = append(messages, fmt.Sprintf("%s %s: %s", m.timestamp, strings.ToUpper(m.messageType), m.message))
 	}
-	message = strings.Join(messages, "<br />")
+
+	data := notify.Data{
+		Config:    r.config,
+		Messages:  messages,
+		Stats:     r.stats,
+		Hostname:  hostname,
+		StartTime: r.startTime,
+		EndTime:   time.Now(),
+		Error:     r.lastErr,
+	}
+
+	templateText := notify.DefaultTemplate(hasError)
+	templatePath := r.config.NotificationTemplateSuccess
+	if hasError {
+		templatePath = r.config.NotificationTemplateFailure
+	}
+	if templatePath != "" {
+		if b, err := ioutil.ReadFile(templatePath); err == nil {
+			templateText = string(b)
+		} else {
+			log.Printf("Failed reading notification template %s: %s", templatePath, err)
+		}
+	}
+
+	message, err := notify.Render(templateText, data)
+	if err != nil {
+		log.Printf("Failed rendering notification template: %s", err)
+		return r
+	}
+
+	r.emailMessage(hostname, message)
+
+	if len(r.config.NotificationURLs) > 0 {
+		if err := notify.Dispatch(r.config.NotificationURLs, message); err != nil {
+			log.Printf("Failed dispatching notification: %s", err)
+		}
+	}
+
+	return r
+}
+
+// emailMessage sends message over SMTP, if the configuration allows it.
+func (r *result) emailMessage(hostname string, message string) {
+	if r.config.SmtpHostname == "" || r.config.SmtpFromEmail == "" || r.config.NotifyEmail == "" {
+		return
+	}
 
 	m := gomail.NewMessage()
 	m.SetHeader("From", r.config.SmtpFromEmail)
 	m.SetHeader("To", r.config.NotifyEmail)
-	m.SetHeader("Subject", subject)
-	m.SetBody("text/html", message)
+	m.SetHeader("Subject", fmt.Sprintf("[%s]: Backup status", hostname))
+	m.SetBody("text/plain", message)
 
 	d := gomail.NewDialer(r.config.SmtpHostname, r.config.SmtpPort, r.config.SmtpUsername, r.config.SmtpPassword)
 	d.TLSConfig = &tls.Config{InsecureSkipVerify: true}
 
 	d.DialAndSend(m)
-
-	return r
 }
 
 // The entry point
 func main() {
 
+	// The "decrypt" subcommand round-trips an archive produced by a run with
+	// encryption enabled; everything else below is the normal backup flow.
+	if len(os.Args) > 1 && os.Args[1] == "decrypt" {
+		runDecrypt(os.Args[2:])
+		return
+	}
+
+	os.Exit(run())
+}
+
+// run parses flags/config and either performs a single backup or, in daemon
+// mode, keeps running them on a cron schedule. It returns the process exit
+// code instead of calling os.Exit directly, so main can do so after this
+// function (and everything it deferred) has unwound.
+func run() int {
 	// Use default config as default in parsed values from flags
 	defaultConfig := newConfiguration()
 	defaultConfig.loadFromJSONFile(configFileName)
@@ -202,11 +346,6 @@ func main() {
 	// Main config object
 	config := newConfiguration()
 
-	// Create the result object
-	res := &result{
-		config: config,
-	}
-
 	// Define the variables we will use, with default on ENV variables
 	flag.StringVar(&config.Endpoint, "endpoint", defaultConfig.Endpoint, "the endpoint")
 	flag.StringVar(&config.AccessKeyID, "access-key-id", defaultConfig.AccessKeyID, "the access key id")
@@ -215,10 +354,20 @@ func main() {
 	flag.BoolVar(&config.UseSSL, "ssl", defaultConfig.UseSSL, "whether to use ssl")
 	flag.StringVar(&config.Location, "location", defaultConfig.Location, "the location name")
 
-	flag.IntVar(&config.MaxBackups, "max-backups", defaultConfig.MaxBackups, "maximum number of backups to keep")
+	flag.IntVar(&config.MaxBackups, "max-backups", defaultConfig.MaxBackups, "deprecated, use -retention-last: maximum number of backups to keep")
 	flag.StringVar(&config.BackupPrefix, "backup-prefix", defaultConfig.BackupPrefix, "backup prefix")
 	flag.StringVar(&config.BackupFolder, "backup-folder", defaultConfig.BackupFolder, "the folder to backup")
 
+	flag.IntVar(&config.RetentionLastN, "retention-last", defaultConfig.RetentionLastN, "keep the last N backups")
+	flag.IntVar(&config.RetentionHourly, "retention-hourly", defaultConfig.RetentionHourly, "keep one backup for each of the last N hours")
+	flag.IntVar(&config.RetentionDaily, "retention-daily", defaultConfig.RetentionDaily, "keep one backup for each of the last N days")
+	flag.IntVar(&config.RetentionWeekly, "retention-weekly", defaultConfig.RetentionWeekly, "keep one backup for each of the last N weeks")
+	flag.IntVar(&config.RetentionMonthly, "retention-monthly", defaultConfig.RetentionMonthly, "keep one backup for each of the last N months")
+	flag.IntVar(&config.RetentionYearly, "retention-yearly", defaultConfig.RetentionYearly, "keep one backup for each of the last N years")
+	flag.StringVar(&config.PruningLeeway, "pruning-leeway", defaultConfig.PruningLeeway, "grace period (e.g. 1h) before a backup becomes eligible for pruning")
+	flag.BoolVar(&config.PruningDryRun, "pruning-dry-run", defaultConfig.PruningDryRun, "log what would be pruned without deleting anything")
+	flag.BoolVar(&config.RetentionTimestampFromName, "retention-timestamp-from-name", defaultConfig.RetentionTimestampFromName, "bucket backups by the timestamp in their object name instead of the backend's reported last-modified time")
+
 	flag.StringVar(&config.SmtpHostname, "smtp-hostname", defaultConfig.SmtpHostname, "the hostname used for the smtp server")
 	flag.IntVar(&config.SmtpPort, "smtp-port", defaultConfig.SmtpPort, "the port used for the smtp server")
 	flag.StringVar(&config.SmtpUsername, "smtp-username", defaultConfig.SmtpUsername, "the username used for the smtp server")
@@ -229,8 +378,73 @@ func main() {
 	flag.BoolVar(&config.NotifyError, "notify-error", defaultConfig.NotifyError, "whether to notify on error messages")
 	flag.StringVar(&config.NotifyEmail, "notify-email", defaultConfig.NotifyEmail, "to whom to send the email notification")
 
+	flag.StringVar(&config.EncryptionMode, "encryption-mode", defaultConfig.EncryptionMode, "archive encryption mode: none, gpg, age or symmetric")
+	flag.StringVar(&config.EncryptionPublicKey, "encryption-public-key", defaultConfig.EncryptionPublicKey, "path to the gpg keyring / age recipients file used to encrypt the archive")
+	flag.StringVar(&config.EncryptionPrivateKey, "encryption-private-key", defaultConfig.EncryptionPrivateKey, "path to the age identity file used to decrypt (unused for gpg)")
+	flag.StringVar(&config.EncryptionPassphrase, "encryption-passphrase", defaultConfig.EncryptionPassphrase, "passphrase used for symmetric/age encryption")
+
+	flag.StringVar(&config.ArchiveFormat, "archive-format", defaultConfig.ArchiveFormat, "archive format: zip, tar.gz or tar.zst")
+	flag.BoolVar(&config.Streaming, "streaming", defaultConfig.Streaming, "stream the archive directly into the upload instead of staging it on disk")
+	flag.UintVar(&config.StreamConcurrency, "stream-concurrency", defaultConfig.StreamConcurrency, "number of concurrent multipart upload threads used while streaming")
+	flag.StringVar(&config.TempDir, "temp-dir", defaultConfig.TempDir, "directory to stage the archive in when streaming is disabled")
+
+	flag.StringVar(&config.PreBackupCommand, "pre-backup-command", defaultConfig.PreBackupCommand, "command run (via sh -c) before archiving, e.g. to dump a database into backup-folder")
+	flag.StringVar(&config.PostBackupCommand, "post-backup-command", defaultConfig.PostBackupCommand, "command run (via sh -c) after a successful backup")
+	flag.StringVar(&config.OnErrorCommand, "on-error-command", defaultConfig.OnErrorCommand, "command run (via sh -c) if the backup fails")
+
+	flag.BoolVar(&config.StopContainers, "stop-containers", defaultConfig.StopContainers, "stop docker containers matching stop-container-label for the duration of the backup, then restart them")
+	flag.StringVar(&config.StopContainerLabel, "stop-container-label", defaultConfig.StopContainerLabel, "\"key=value\" label of the docker containers to stop/restart")
+
+	flag.BoolVar(&config.Daemon, "daemon", defaultConfig.Daemon, "run in-process on a cron schedule instead of exiting after a single backup")
+	flag.StringVar(&config.Schedule, "schedule", defaultConfig.Schedule, "cron expression used in daemon mode, e.g. \"0 3 * * *\"")
+	flag.StringVar(&config.ListenAddr, "listen-addr", defaultConfig.ListenAddr, "address the /healthz and /metrics endpoints listen on in daemon mode")
+	flag.StringVar(&config.LockFile, "lock-file", defaultConfig.LockFile, "file lock path preventing overlapping runs")
+
 	flag.Parse()
 
+	if config.Daemon {
+		return runDaemon(config)
+	}
+
+	// A single run takes the same lock as the daemon's scheduled runs, so
+	// it can't overlap one of them or another single run.
+	code, ran := 1, false
+	if err := daemon.WithLock(config.LockFile, func() error {
+		_, code = performBackup(config)
+		ran = true
+		return nil
+	}); err != nil {
+		log.Printf("%s", err)
+		return 1
+	}
+	if !ran {
+		log.Printf("Another backup holds the lock %s, skipping this run", config.LockFile)
+	}
+	return code
+}
+
+// performBackup runs a single backup and returns the accumulated result
+// together with the process exit code fatal()/ok() panicked with. Every
+// code path below ends by calling one of them, so the recover here always
+// observes an exitCode.
+func performBackup(config *configuration) (res *result, code int) {
+	res = &result{
+		config:    config,
+		startTime: time.Now(),
+	}
+
+	defer func() {
+		rec := recover()
+		if rec == nil {
+			return
+		}
+		ec, ok := rec.(exitCode)
+		if !ok {
+			panic(rec)
+		}
+		code = int(ec)
+	}()
+
 	// Some basic checks before anything else
 	if len(strings.TrimSpace(config.Endpoint)) == 0 {
 		res.message(newResultMessage(resultInfo, "Please specify an endpoint: --endpoint=...")).fatal()
@@ -259,80 +473,205 @@ func main() {
 
 	res.message(newResultMessage(resultInfo, fmt.Sprintf("Starting backup for %s", config.BackupFolder)))
 
-	// Initialize minio client object.
-	minioClient, err := minio.New(config.Endpoint, config.AccessKeyID, config.SecretAccessKey, config.UseSSL)
+	// Build the enabled storage backends (defaults to a single S3/MinIO
+	// backend built from the legacy flags when none are configured).
+	backends, err := buildBackends(config)
 	if err != nil {
 		res.message(newResultMessage(resultError, err.Error())).email().fatal()
 	}
-
-	// Create the bucket if it does not exists
-	if err = minioClient.MakeBucket(config.BucketName, config.Location); err != nil {
-		// Check to see if we already own this bucket (which happens if you run this twice)
-		exists, err := minioClient.BucketExists(config.BucketName)
-		if err == nil && exists {
-			res.message(newResultMessage(resultInfo, fmt.Sprintf("We already own %s", config.BucketName)))
-		} else {
-			res.message(newResultMessage(resultError, err.Error())).email().fatal()
-		}
+	if len(backends) == 0 {
+		res.message(newResultMessage(resultInfo, "No enabled backends, nothing to do")).email().fatal()
 	}
-	res.message(newResultMessage(resultInfo, fmt.Sprintf("Using bucket: %s", config.BucketName)))
 
-	// List all objects from a bucket-name with a matching prefix.
-	doneCh := make(chan struct{})
-	defer close(doneCh)
+	ctx := context.Background()
 
-	// Populate a slice of minio.ObjectInfo
-	var objects []minio.ObjectInfo
-	for object := range minioClient.ListObjectsV2(config.BucketName, config.BackupPrefix, true, doneCh) {
-		if object.Err != nil {
-			res.message(newResultMessage(resultError, object.Err.Error()))
-			continue
+	if err := hooks.Run(ctx, config.PreBackupCommand, res.hookEnv(resultInfo)); err != nil {
+		res.message(newResultMessage(resultError, fmt.Sprintf("pre-backup-command failed: %s", err))).email().fatal()
+	}
+
+	// Stop containers carrying StopContainerLabel for the duration of the
+	// backup, and make sure they're restarted even if a later step fails:
+	// the defer runs during the stack unwind triggered by fatal()/ok()'s
+	// panic, before performBackup's recover turns it into an exit code.
+	if config.StopContainers {
+		stopped, err := dockerctl.StopLabeled(ctx, config.StopContainerLabel)
+		if err != nil {
+			res.message(newResultMessage(resultError, fmt.Sprintf("Failed stopping containers labeled %s: %s", config.StopContainerLabel, err)))
 		}
-		objects = append(objects, object)
-	}
-
-	// Make sure we only keep latest X backups
-	if config.MaxBackups > 0 && len(objects) > config.MaxBackups {
-		// remove newer X backups from the slice and leave only the one to be deleted
-		objects = objects[:len(objects)-config.MaxBackups]
-		for _, object := range objects {
-			err = minioClient.RemoveObject(config.BucketName, object.Key)
-			if err != nil {
-				res.message(newResultMessage(resultError, err.Error()))
-				continue
-			}
-			res.message(newResultMessage(resultSuccess, fmt.Sprintf("Successfully removed remote object: %s", object.Key)))
+		if len(stopped) > 0 {
+			res.message(newResultMessage(resultInfo, fmt.Sprintf("Stopped %d container(s) labeled %s for the duration of the backup", len(stopped), config.StopContainerLabel)))
 		}
+		defer func() {
+			if err := dockerctl.Restart(ctx, stopped); err != nil {
+				res.message(newResultMessage(resultError, fmt.Sprintf("Failed restarting containers: %s", err)))
+			}
+		}()
+	}
+
+	archiveFormat := archive.Format(config.ArchiveFormat)
+	if archiveFormat == "" {
+		archiveFormat = archive.FormatZip
+	}
+
+	encConfig := encrypt.Config{
+		Mode:       encrypt.Mode(config.EncryptionMode),
+		PublicKey:  config.EncryptionPublicKey,
+		PrivateKey: config.EncryptionPrivateKey,
+		Passphrase: config.EncryptionPassphrase,
+	}
+
+	archiveName := fmt.Sprintf("%s%s%s", config.BackupPrefix, time.Now().Format("2006-01-02.15-04-05"), archiveFormat.Extension())
+
+	// Streaming pipes the archive straight into a single backend's upload,
+	// so nothing is ever staged twice on disk. It requires a streamable
+	// archive format, a single backend (the archive can only be read once)
+	// and no encryption stage (which today only knows how to work on files).
+	canStream := config.Streaming && archiveFormat.Streamable() && encConfig.Mode == encrypt.ModeNone && len(backends) == 1
+
+	if canStream {
+		res.archivePath = archiveName
+		runStreamed(ctx, res, config, backends[0], archiveFormat, archiveName)
+		res.stats.Elapsed = time.Since(res.startTime)
+		res.email().ok()
 	}
 
-	// Create the backup archive locally, in /tmp
-	archiveName := fmt.Sprintf("%s%s.zip", config.BackupPrefix, time.Now().Format("2006-01-02.15-04-05"))
-	tmpFilePath := fmt.Sprintf("/tmp/%s", archiveName)
+	// Otherwise, fall back to staging the archive (and its encrypted
+	// counterpart, if any) in TempDir before uploading it to every backend.
+	tempDir := config.TempDir
+	if tempDir == "" {
+		tempDir = "/tmp"
+	}
+	tmpFilePath := filepath.Join(tempDir, archiveName)
+	res.archivePath = tmpFilePath
 
 	res.message(newResultMessage(resultInfo, fmt.Sprintf("Creating: %s which will contain the contents of: %s", tmpFilePath, config.BackupFolder)))
 
-	// And make the zip
-	if err = archiver.Zip.Make(tmpFilePath, []string{config.BackupFolder}); err != nil {
+	if err = archive.MakeFile(archiveFormat, tmpFilePath, []string{config.BackupFolder}); err != nil {
 		res.message(newResultMessage(resultError, err.Error())).email().fatal()
 	}
 
-	// Upload the zip file with FPutObject
-	n, err := minioClient.FPutObject(config.BucketName, archiveName, tmpFilePath, minio.PutObjectOptions{ContentType: "application/zip"})
-	if err != nil {
-		res.message(newResultMessage(resultError, err.Error())).email().fatal()
+	// Encrypt the archive, if configured. The encrypted file (not the
+	// plaintext archive) is what gets uploaded and pruned.
+	uploadName := archiveName
+	uploadFilePath := tmpFilePath
+	if encConfig.Extension() != "" {
+		uploadName = archiveName + encConfig.Extension()
+		uploadFilePath = tmpFilePath + encConfig.Extension()
+
+		res.message(newResultMessage(resultInfo, fmt.Sprintf("Encrypting %s to %s using mode %q", tmpFilePath, uploadFilePath, encConfig.Mode)))
+
+		if err = encrypt.Encrypt(encConfig, tmpFilePath, uploadFilePath); err != nil {
+			res.message(newResultMessage(resultError, err.Error())).email().fatal()
+		}
+	}
+	res.archivePath = uploadFilePath
+
+	if info, err := os.Stat(uploadFilePath); err == nil {
+		res.stats.ArchiveSize = info.Size()
+	}
+
+	// Run the upload/prune cycle against every enabled backend. A failure on
+	// one backend doesn't stop the others, but if every backend failed the
+	// backup didn't actually land anywhere, so treat that as fatal.
+	failures := 0
+	for _, backend := range backends {
+		if err := runBackend(ctx, res, config, backend, uploadName, uploadFilePath); err != nil {
+			failures++
+		}
+	}
+	if failures == len(backends) {
+		res.message(newResultMessage(resultError, "All backends failed, aborting")).email().fatal()
 	}
 
-	// Upload went okay
-	res.message(newResultMessage(resultSuccess, fmt.Sprintf("Successfully uploaded %s of size %d", archiveName, n)))
+	res.stats.Elapsed = time.Since(res.startTime)
 
-	// Remove created archive
+	// Remove the created archive (and its encrypted counterpart, if any)
 	if err = os.Remove(tmpFilePath); err != nil {
 		res.message(newResultMessage(resultError, err.Error())).email().fatal()
 	}
+	if uploadFilePath != tmpFilePath {
+		if err = os.Remove(uploadFilePath); err != nil {
+			res.message(newResultMessage(resultError, err.Error())).email().fatal()
+		}
+	}
 
 	// Removed the object
-	res.message(newResultMessage(resultSuccess, fmt.Sprintf("Successfully removed %s from local storage", archiveName)))
+	res.message(newResultMessage(resultSuccess, fmt.Sprintf("Successfully removed %s from local storage", uploadName)))
 
 	// We're done, all went okay
 	res.email().ok()
+	return // unreachable: ok() panics to unwind through the deferred cleanup above
+}
+
+// runStreamed prunes old backups on backend, then pipes the archive of
+// config.BackupFolder directly into its upload without ever touching disk.
+func runStreamed(ctx context.Context, res *result, config *configuration, backend storage.Backend, archiveFormat archive.Format, archiveName string) {
+	res.message(newResultMessage(resultInfo, fmt.Sprintf("[%s] Streaming %s (format %s) directly to backend", backend.Name(), config.BackupFolder, archiveFormat)))
+
+	pruneBackend(ctx, res, config, backend)
+
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(archive.Stream(archiveFormat, pw, []string{config.BackupFolder}))
+	}()
+
+	if err := backend.Upload(ctx, pr, -1, archiveName); err != nil {
+		res.message(newResultMessage(resultError, fmt.Sprintf("[%s] %s", backend.Name(), err.Error()))).email().fatal()
+	}
+
+	res.message(newResultMessage(resultSuccess, fmt.Sprintf("[%s] Successfully uploaded %s", backend.Name(), archiveName)))
+}
+
+// runBackend prunes old backups on backend and uploads the freshly created
+// archive to it. It returns an error (already recorded on res) when the
+// upload itself failed, so the caller can tell a failed backend apart from
+// a successful one.
+func runBackend(ctx context.Context, res *result, config *configuration, backend storage.Backend, archiveName string, tmpFilePath string) error {
+	res.message(newResultMessage(resultInfo, fmt.Sprintf("[%s] Using backend", backend.Name())))
+
+	pruneBackend(ctx, res, config, backend)
+
+	src, err := os.Open(tmpFilePath)
+	if err != nil {
+		res.message(newResultMessage(resultError, fmt.Sprintf("[%s] %s", backend.Name(), err.Error())))
+		return err
+	}
+	defer src.Close()
+
+	if err := backend.Upload(ctx, src, res.stats.ArchiveSize, archiveName); err != nil {
+		res.message(newResultMessage(resultError, fmt.Sprintf("[%s] %s", backend.Name(), err.Error())))
+		return err
+	}
+
+	res.stats.BytesUploaded += res.stats.ArchiveSize
+	res.message(newResultMessage(resultSuccess, fmt.Sprintf("[%s] Successfully uploaded %s", backend.Name(), archiveName)))
+	return nil
+}
+
+// pruneBackend removes backend's backups that fall outside its configured
+// retention policy (keep-last-N plus keep-hourly/daily/weekly/monthly/yearly
+// windows), honoring PruningLeeway and PruningDryRun.
+func pruneBackend(ctx context.Context, res *result, config *configuration, backend storage.Backend) {
+	objects, err := backend.List(ctx, config.BackupPrefix)
+	if err != nil {
+		res.message(newResultMessage(resultError, fmt.Sprintf("[%s] %s", backend.Name(), err.Error())))
+		return
+	}
+
+	policy := retentionPolicyFor(config, backend.Name())
+	plan := retention.Apply(policy, objects, time.Now())
+
+	for _, object := range plan.Remove {
+		if config.PruningDryRun {
+			res.message(newResultMessage(resultInfo, fmt.Sprintf("[%s] (dry-run) Would remove remote object: %s", backend.Name(), object.Key)))
+			continue
+		}
+
+		if err := backend.Delete(ctx, object.Key); err != nil {
+			res.message(newResultMessage(resultError, fmt.Sprintf("[%s] %s", backend.Name(), err.Error())))
+			continue
+		}
+		res.message(newResultMessage(resultSuccess, fmt.Sprintf("[%s] Successfully removed remote object: %s", backend.Name(), object.Key)))
+		res.stats.FilesPruned++
+	}
 }
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/twisted1919/minio-backup/internal/retention"
+	"github.com/twisted1919/minio-backup/internal/storage"
+)
+
+// retentionPolicyFor builds the retention.Policy for the named backend,
+// falling back to the global retention settings (and the deprecated
+// MaxBackups/max-backups field, mapped onto RetentionLastN) when the
+// backend doesn't override them.
+func retentionPolicyFor(config *configuration, backendName string) retention.Policy {
+	policy := retention.Policy{
+		Last:    config.RetentionLastN,
+		Hourly:  config.RetentionHourly,
+		Daily:   config.RetentionDaily,
+		Weekly:  config.RetentionWeekly,
+		Monthly: config.RetentionMonthly,
+		Yearly:  config.RetentionYearly,
+		Leeway:  parseLeeway(config.PruningLeeway),
+	}
+	if config.RetentionTimestampFromName {
+		policy.TimestampOf = func(o storage.Object) time.Time {
+			if ts, ok := retention.TimestampFromName(config.BackupPrefix, o.Key); ok {
+				return ts
+			}
+			return o.LastModified
+		}
+	}
+	if policy.Last == 0 {
+		policy.Last = config.MaxBackups
+	}
+
+	if bc, ok := findBackendConfig(config, backendName); ok {
+		if bc.RetentionLastN > 0 {
+			policy.Last = bc.RetentionLastN
+		} else if bc.MaxBackups > 0 {
+			policy.Last = bc.MaxBackups
+		}
+		if bc.RetentionHourly > 0 {
+			policy.Hourly = bc.RetentionHourly
+		}
+		if bc.RetentionDaily > 0 {
+			policy.Daily = bc.RetentionDaily
+		}
+		if bc.RetentionWeekly > 0 {
+			policy.Weekly = bc.RetentionWeekly
+		}
+		if bc.RetentionMonthly > 0 {
+			policy.Monthly = bc.RetentionMonthly
+		}
+		if bc.RetentionYearly > 0 {
+			policy.Yearly = bc.RetentionYearly
+		}
+	}
+
+	return policy
+}
+
+func parseLeeway(leeway string) time.Duration {
+	if leeway == "" {
+		return 0
+	}
+	d, err := time.ParseDuration(leeway)
+	if err != nil {
+		log.Printf("Invalid pruning-leeway %q, ignoring: %s", leeway, err)
+		return 0
+	}
+	return d
+}